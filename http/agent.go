@@ -23,6 +23,7 @@ type AgentRequest struct {
 	ID        string
 	Operation Operation
 	Path      string
+	Identity  Identity
 }
 
 func buildAgentRequest(req *http.Request) *AgentRequest {
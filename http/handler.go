@@ -9,15 +9,20 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/hashicorp/go-hclog"
 	realm "github.com/steviebps/realm/pkg"
 	"github.com/steviebps/realm/pkg/storage"
+	"github.com/steviebps/realm/pkg/storage/snapshot"
 	"github.com/steviebps/realm/utils"
 )
 
+// operatorRole is the role required to call the snapshot/restore endpoints.
+const operatorRole = "operator"
+
 type OperationResponse struct {
 	Data  any    `json:"data,omitempty"`
 	Error string `json:"error,omitempty"`
@@ -27,6 +32,16 @@ type HandlerConfig struct {
 	Logger         hclog.Logger
 	Storage        storage.Storage
 	RequestTimeout time.Duration
+	Authenticator  TokenAuthenticator
+	Policy         *Policy
+
+	// Compression selects a registered storage codec ("identity", "gzip",
+	// "zstd") to transparently compress stored values. Left empty, values
+	// are stored as Storage receives them.
+	Compression string
+	// CompressionThreshold is the minimum value size, in bytes, that gets
+	// compressed when Compression is set. Smaller values are stored raw.
+	CompressionThreshold int
 }
 
 func NewHandler(config HandlerConfig) (http.Handler, error) {
@@ -36,18 +51,36 @@ func NewHandler(config HandlerConfig) (http.Handler, error) {
 	if config.Logger == nil {
 		config.Logger = hclog.Default().Named("realm")
 	}
+	if config.Compression != "" {
+		tag, codec, err := storage.CodecByName(config.Compression)
+		if err != nil {
+			return nil, err
+		}
+		config.Storage = storage.NewCompressedStorage(config.Storage, tag, codec, config.CompressionThreshold)
+	}
 	return handle(config), nil
 }
 
 func handle(hc HandlerConfig) http.Handler {
 	logger := hc.Logger.Named("http")
 	strg := hc.Storage
+	policy := hc.Policy
 	mux := http.NewServeMux()
 	mux.HandleFunc("/v1/", func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
-		requestLogger := logger.With("method", r.Method, "path", r.URL.Path)
+		identity, _ := IdentityFromContext(ctx)
+		agentReq := buildAgentRequest(r)
+		agentReq.Identity = identity
+
+		requestLogger := logger.With("method", r.Method, "path", r.URL.Path, "request_id", agentReq.ID, "identity", identity.Name)
 		loggerCtx := hclog.WithContext(ctx, requestLogger)
 
+		if !policy.Allows(identity, agentReq.Operation, agentReq.Path) {
+			requestLogger.Warn("identity is not permitted to perform this operation", "operation", agentReq.Operation)
+			handleResponse(w, http.StatusForbidden, nil, http.StatusText(http.StatusForbidden))
+			return
+		}
+
 		path := strings.TrimPrefix(r.URL.Path, "/v1")
 		switch r.Method {
 		case http.MethodGet:
@@ -58,6 +91,11 @@ func handle(hc HandlerConfig) http.Handler {
 				return
 			}
 
+			if watch, _ := strconv.ParseBool(r.URL.Query().Get("watch")); watch {
+				handleWatch(loggerCtx, w, r, strg, path, requestLogger)
+				return
+			}
+
 			entry, err := strg.Get(loggerCtx, path)
 			if err != nil {
 				msg := err.Error()
@@ -145,11 +183,89 @@ func handle(hc HandlerConfig) http.Handler {
 		}
 	})
 
-	return wrapWithTimeout(mux, hc.RequestTimeout)
+	mux.HandleFunc("/v1/operator/snapshot", func(w http.ResponseWriter, r *http.Request) {
+		handleSnapshot(w, r, strg, logger)
+	})
+	mux.HandleFunc("/v1/operator/restore", func(w http.ResponseWriter, r *http.Request) {
+		handleRestore(w, r, strg, logger)
+	})
+
+	return wrapWithAuth(wrapWithTimeout(mux, hc.RequestTimeout), hc.Authenticator)
+}
+
+func handleSnapshot(w http.ResponseWriter, r *http.Request, strg storage.Storage, logger hclog.Logger) {
+	ctx := r.Context()
+	identity, _ := IdentityFromContext(ctx)
+	requestLogger := logger.With("method", r.Method, "path", r.URL.Path, "identity", identity.Name)
+
+	if r.Method != http.MethodPost {
+		handleResponse(w, http.StatusMethodNotAllowed, nil, http.StatusText(http.StatusMethodNotAllowed))
+		return
+	}
+	if !hasRole(identity, operatorRole) {
+		requestLogger.Warn("identity is not permitted to snapshot storage")
+		handleResponse(w, http.StatusForbidden, nil, http.StatusText(http.StatusForbidden))
+		return
+	}
+
+	archive, err := snapshot.New(strg).Create(ctx, r.URL.Query().Get("prefix"))
+	if err != nil {
+		requestLogger.Error(err.Error())
+		handleResponse(w, http.StatusInternalServerError, nil, err.Error())
+		return
+	}
+	defer archive.Close()
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, archive); err != nil {
+		requestLogger.Error(err.Error())
+	}
+}
+
+func handleRestore(w http.ResponseWriter, r *http.Request, strg storage.Storage, logger hclog.Logger) {
+	ctx := r.Context()
+	identity, _ := IdentityFromContext(ctx)
+	requestLogger := logger.With("method", r.Method, "path", r.URL.Path, "identity", identity.Name)
+
+	if r.Method != http.MethodPost {
+		handleResponse(w, http.StatusMethodNotAllowed, nil, http.StatusText(http.StatusMethodNotAllowed))
+		return
+	}
+	if !hasRole(identity, operatorRole) {
+		requestLogger.Warn("identity is not permitted to restore storage")
+		handleResponse(w, http.StatusForbidden, nil, http.StatusText(http.StatusForbidden))
+		return
+	}
+
+	force, _ := strconv.ParseBool(r.URL.Query().Get("force"))
+	opts := snapshot.RestoreOptions{Prefix: r.URL.Query().Get("prefix"), Force: force}
+
+	if err := snapshot.New(strg).Restore(ctx, r.Body, opts); err != nil {
+		requestLogger.Error(err.Error())
+		handleResponse(w, http.StatusInternalServerError, nil, err.Error())
+		return
+	}
+
+	handleResponse(w, http.StatusOK, nil, "")
+}
+
+func hasRole(identity Identity, role string) bool {
+	for _, candidate := range identity.Roles {
+		if candidate == role {
+			return true
+		}
+	}
+	return false
 }
 
 func wrapWithTimeout(h http.Handler, t time.Duration) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if bypassRequestTimeout(r) {
+			h.ServeHTTP(w, r)
+			return
+		}
+
 		ctx := r.Context()
 		var cancelFunc context.CancelFunc
 		ctx, cancelFunc = context.WithTimeout(ctx, t)
@@ -159,6 +275,130 @@ func wrapWithTimeout(h http.Handler, t time.Duration) http.Handler {
 	})
 }
 
+// bypassRequestTimeout reports whether r is a streaming request that
+// shouldn't be bound by RequestTimeout: a watch upgrade, or an operator
+// snapshot/restore, either of which can legitimately run far longer than an
+// ordinary get/put/list call and would otherwise be cut off mid-stream.
+func bypassRequestTimeout(r *http.Request) bool {
+	if watch, _ := strconv.ParseBool(r.URL.Query().Get("watch")); watch {
+		return true
+	}
+
+	return strings.HasPrefix(r.URL.Path, "/v1/operator/")
+}
+
+// watchHeartbeatInterval is how often handleWatch emits a comment line to
+// keep intermediaries from closing an otherwise idle streaming connection.
+const watchHeartbeatInterval = 30 * time.Second
+
+// subscribeAtRevisioner is implemented by storage backends that can bound
+// the replay window to the revision a watcher actually subscribed at,
+// closing the gap where a write racing the subscription would otherwise be
+// delivered both in the replay and on the live channel.
+type subscribeAtRevisioner interface {
+	SubscribeAt(ctx context.Context, pathPrefix string) (<-chan storage.Event, uint64, error)
+}
+
+// revisionedReplayer is implemented by storage backends that can bound
+// EventsSince's replay to events no newer than the revision a watcher
+// subscribed at.
+type revisionedReplayer interface {
+	EventsSince(pathPrefix string, from, upTo uint64) []storage.Event
+}
+
+// handleWatch upgrades a GET request into a Server-Sent Events stream of
+// storage.Event values under path. When strg implements both
+// subscribeAtRevisioner and revisionedReplayer, it replays any buffered
+// events newer than ?fromRevision= before switching to live streaming; a
+// backend that only implements storage.Notifier gets live events with no
+// replay. It emits a heartbeat comment every watchHeartbeatInterval while
+// idle.
+func handleWatch(ctx context.Context, w http.ResponseWriter, r *http.Request, strg storage.Storage, path string, logger hclog.Logger) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		handleResponse(w, http.StatusInternalServerError, nil, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	var fromRevision uint64
+	if raw := r.URL.Query().Get("fromRevision"); raw != "" {
+		parsed, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			handleResponse(w, http.StatusBadRequest, nil, "fromRevision must be a non-negative integer")
+			return
+		}
+		fromRevision = parsed
+	}
+
+	var events <-chan storage.Event
+	var replay []storage.Event
+
+	switch subscriber := strg.(type) {
+	case subscribeAtRevisioner:
+		ch, subscribedAt, err := subscriber.SubscribeAt(ctx, path)
+		if err != nil {
+			logger.Error(err.Error())
+			handleResponse(w, http.StatusInternalServerError, nil, http.StatusText(http.StatusInternalServerError))
+			return
+		}
+		events = ch
+
+		if replayer, ok := strg.(revisionedReplayer); ok {
+			replay = replayer.EventsSince(path, fromRevision, subscribedAt)
+		}
+	case storage.Notifier:
+		ch, err := subscriber.Subscribe(ctx, path)
+		if err != nil {
+			logger.Error(err.Error())
+			handleResponse(w, http.StatusInternalServerError, nil, http.StatusText(http.StatusInternalServerError))
+			return
+		}
+		events = ch
+	default:
+		handleResponse(w, http.StatusNotImplemented, nil, "storage backend does not support watch")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	for _, evt := range replay {
+		writeWatchEvent(w, evt)
+	}
+	if len(replay) > 0 {
+		flusher.Flush()
+	}
+
+	heartbeat := time.NewTicker(watchHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case evt, ok := <-events:
+			if !ok {
+				return
+			}
+			writeWatchEvent(w, evt)
+			flusher.Flush()
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+func writeWatchEvent(w http.ResponseWriter, evt storage.Event) {
+	data, err := json.Marshal(evt)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "data: %s\n\n", data)
+}
+
 func handleResponse(w http.ResponseWriter, statusCode int, data any, error string) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
@@ -0,0 +1,41 @@
+package http
+
+import "strings"
+
+// PolicyRule grants identities holding Role the listed Operations on any
+// path beneath PathPrefix.
+type PolicyRule struct {
+	Role       string
+	PathPrefix string
+	Operations []Operation
+}
+
+// Policy is a role-based access policy gating each Operation by path prefix,
+// letting read-only clients be issued tokens that cannot mutate chambers.
+type Policy struct {
+	Rules []PolicyRule
+}
+
+// Allows reports whether identity may perform op against path. A nil Policy
+// allows everything so existing unauthenticated deployments are unaffected.
+func (p *Policy) Allows(identity Identity, op Operation, path string) bool {
+	if p == nil {
+		return true
+	}
+
+	for _, role := range identity.Roles {
+		for _, rule := range p.Rules {
+			if rule.Role != role || !strings.HasPrefix(path, rule.PathPrefix) {
+				continue
+			}
+
+			for _, allowed := range rule.Operations {
+				if allowed == op {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,240 @@
+package storage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// CodecTag is the single-byte prefix a CompressedStorage stamps onto every
+// stored value so it can be decoded without knowing which codec wrote it.
+type CodecTag byte
+
+const (
+	// IdentityCodecTag marks a value that was stored uncompressed, either
+	// because compression was disabled or it fell under the threshold.
+	IdentityCodecTag CodecTag = iota
+	GzipCodecTag
+	ZstdCodecTag
+)
+
+// Codec encodes and decodes stored values. Decode(Encode(b)) must return b.
+type Codec interface {
+	Encode(b []byte) ([]byte, error)
+	Decode(b []byte) ([]byte, error)
+}
+
+type identityCodec struct{}
+
+func (identityCodec) Encode(b []byte) ([]byte, error) { return b, nil }
+func (identityCodec) Decode(b []byte) ([]byte, error) { return b, nil }
+
+type gzipCodec struct{}
+
+func (gzipCodec) Encode(b []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	if _, err := gw.Write(b); err != nil {
+		return nil, err
+	}
+	if err := gw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gzipCodec) Decode(b []byte) ([]byte, error) {
+	gr, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	defer gr.Close()
+	return io.ReadAll(gr)
+}
+
+type zstdCodec struct{}
+
+func (zstdCodec) Encode(b []byte) ([]byte, error) {
+	enc, err := zstd.NewWriter(nil)
+	if err != nil {
+		return nil, err
+	}
+	defer enc.Close()
+	return enc.EncodeAll(b, nil), nil
+}
+
+func (zstdCodec) Decode(b []byte) ([]byte, error) {
+	dec, err := zstd.NewReader(nil)
+	if err != nil {
+		return nil, err
+	}
+	defer dec.Close()
+	return dec.DecodeAll(b, nil)
+}
+
+var codecsByTag = map[CodecTag]Codec{
+	IdentityCodecTag: identityCodec{},
+	GzipCodecTag:     gzipCodec{},
+	ZstdCodecTag:     zstdCodec{},
+}
+
+var codecTagsByName = map[string]CodecTag{
+	"identity": IdentityCodecTag,
+	"gzip":     GzipCodecTag,
+	"zstd":     ZstdCodecTag,
+}
+
+// CodecByName resolves one of the built-in codec names ("identity", "gzip",
+// "zstd") to the CodecTag and Codec a CompressedStorage should be configured
+// with.
+func CodecByName(name string) (CodecTag, Codec, error) {
+	tag, ok := codecTagsByName[name]
+	if !ok {
+		return 0, nil, fmt.Errorf("unknown storage codec %q", name)
+	}
+	return tag, codecsByTag[tag], nil
+}
+
+// compressedMagic prefixes every value CompressedStorage writes so Get can
+// tell a tagged entry apart from one written before compression was
+// enabled. Real chamber values are JSON documents and never start with
+// these bytes, so an untagged legacy entry is detected and passed through
+// unmodified instead of having its leading byte misread as a CodecTag.
+var compressedMagic = []byte("RLMC")
+
+// CompressedStorage wraps a Storage, transparently compressing values with
+// Codec before writing and decompressing them on read. Every stored value
+// is prefixed with compressedMagic and a single-byte CodecTag so future
+// codecs can be introduced without a migration, while entries written
+// before compression was enabled - which carry neither - keep decoding as
+// the raw bytes they always were. Values smaller than Threshold bytes are
+// stored raw to avoid the CPU cost on tiny toggles.
+type CompressedStorage struct {
+	Storage
+
+	Tag       CodecTag
+	Codec     Codec
+	Threshold int
+}
+
+// NewCompressedStorage decorates s with transparent compression.
+func NewCompressedStorage(s Storage, tag CodecTag, codec Codec, threshold int) *CompressedStorage {
+	return &CompressedStorage{Storage: s, Tag: tag, Codec: codec, Threshold: threshold}
+}
+
+// Put compresses entry.Value with Codec when it meets Threshold, tags it,
+// and stores the result.
+func (c *CompressedStorage) Put(ctx context.Context, entry StorageEntry) error {
+	tag := IdentityCodecTag
+	value := entry.Value
+
+	if len(entry.Value) >= c.Threshold {
+		encoded, err := c.Codec.Encode(entry.Value)
+		if err != nil {
+			return err
+		}
+		tag = c.Tag
+		value = encoded
+	}
+
+	tagged := make([]byte, 0, len(compressedMagic)+1+len(value))
+	tagged = append(tagged, compressedMagic...)
+	tagged = append(tagged, byte(tag))
+	tagged = append(tagged, value...)
+
+	entry.Value = tagged
+	return c.Storage.Put(ctx, entry)
+}
+
+// Subscribe implements Notifier by forwarding to the wrapped Storage, if it
+// supports it. CompressedStorage embeds Storage as an interface field, so Go
+// only promotes the methods declared on that interface (Get/Put/Delete/List)
+// -- not Subscribe or any other method the concrete value underneath happens
+// to implement -- hence the explicit forward rather than relying on
+// embedding to do it.
+func (c *CompressedStorage) Subscribe(ctx context.Context, pathPrefix string) (<-chan Event, error) {
+	notifier, ok := c.Storage.(Notifier)
+	if !ok {
+		return nil, fmt.Errorf("storage: %T does not support Subscribe", c.Storage)
+	}
+	return notifier.Subscribe(ctx, pathPrefix)
+}
+
+// SubscribeAt forwards to the wrapped Storage, if it supports it, for the
+// same reason Subscribe does.
+func (c *CompressedStorage) SubscribeAt(ctx context.Context, pathPrefix string) (<-chan Event, uint64, error) {
+	subscriber, ok := c.Storage.(interface {
+		SubscribeAt(ctx context.Context, pathPrefix string) (<-chan Event, uint64, error)
+	})
+	if !ok {
+		return nil, 0, fmt.Errorf("storage: %T does not support SubscribeAt", c.Storage)
+	}
+	return subscriber.SubscribeAt(ctx, pathPrefix)
+}
+
+// EventsSince forwards to the wrapped Storage, if it supports it, for the
+// same reason Subscribe does.
+func (c *CompressedStorage) EventsSince(pathPrefix string, from, upTo uint64) []Event {
+	replayer, ok := c.Storage.(interface {
+		EventsSince(pathPrefix string, from, upTo uint64) []Event
+	})
+	if !ok {
+		return nil
+	}
+	return replayer.EventsSince(pathPrefix, from, upTo)
+}
+
+// CurrentRevision forwards to the wrapped Storage, if it supports it, for
+// the same reason Subscribe does.
+func (c *CompressedStorage) CurrentRevision() uint64 {
+	revisioned, ok := c.Storage.(interface{ CurrentRevision() uint64 })
+	if !ok {
+		return 0
+	}
+	return revisioned.CurrentRevision()
+}
+
+// Get reads the entry at key and decodes it according to its codec tag.
+func (c *CompressedStorage) Get(ctx context.Context, key string) (StorageEntry, error) {
+	entry, err := c.Storage.Get(ctx, key)
+	if err != nil {
+		return StorageEntry{}, err
+	}
+
+	decoded, err := decodeTagged(entry.Value)
+	if err != nil {
+		return StorageEntry{}, err
+	}
+
+	entry.Value = decoded
+	return entry, nil
+}
+
+func decodeTagged(raw []byte) ([]byte, error) {
+	if !bytes.HasPrefix(raw, compressedMagic) {
+		// Written before compression was enabled (or by something that
+		// bypassed CompressedStorage entirely) -- pass it through as-is
+		// rather than misinterpreting an arbitrary leading byte as a tag.
+		return raw, nil
+	}
+
+	rest := raw[len(compressedMagic):]
+	if len(rest) == 0 {
+		return nil, fmt.Errorf("storage: truncated compressed entry")
+	}
+
+	tag := CodecTag(rest[0])
+	if tag == IdentityCodecTag {
+		return rest[1:], nil
+	}
+
+	codec, ok := codecsByTag[tag]
+	if !ok {
+		return nil, fmt.Errorf("unknown storage codec tag %d", tag)
+	}
+	return codec.Decode(rest[1:])
+}
@@ -0,0 +1,171 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// subscriberBuffer bounds how many events a slow subscriber can fall behind
+// by before further publishes to it are dropped rather than blocking writers.
+const subscriberBuffer = 16
+
+// maxBufferedEvents caps how many past events NotifyingStorage retains for
+// replay. Without a cap a long-running agent's buffer grows forever; once
+// it's full the oldest events are dropped and a watcher resuming from a
+// revision older than what's retained simply won't see them replayed.
+const maxBufferedEvents = 10000
+
+// EventType enumerates the kinds of change a Notifier can report.
+type EventType string
+
+const (
+	// PutEvent indicates a key was created or updated.
+	PutEvent EventType = "put"
+	// DeleteEvent indicates a key was removed.
+	DeleteEvent EventType = "delete"
+)
+
+// Event describes a single change to a stored key, stamped with the
+// revision it was committed at.
+type Event struct {
+	Type     EventType `json:"type"`
+	Key      string    `json:"key"`
+	Value    []byte    `json:"value,omitempty"`
+	Revision uint64    `json:"revision"`
+}
+
+// Notifier lets callers subscribe to changes under a key prefix.
+type Notifier interface {
+	Subscribe(ctx context.Context, pathPrefix string) (<-chan Event, error)
+}
+
+// NotifyingStorage wraps a Storage, fanning out every Put and Delete to
+// subscribers whose prefix matches the written key and stamping each write
+// with a monotonically increasing revision. It buffers the most recent
+// maxBufferedEvents events so a reconnecting watcher can replay what it
+// missed via EventsSince; events older than that are no longer replayed.
+type NotifyingStorage struct {
+	Storage
+
+	mu          sync.Mutex
+	revision    uint64
+	subscribers map[string][]chan Event
+	buffer      []Event
+}
+
+// NewNotifyingStorage decorates s with change notification.
+func NewNotifyingStorage(s Storage) *NotifyingStorage {
+	return &NotifyingStorage{Storage: s, subscribers: map[string][]chan Event{}}
+}
+
+// Put stores entry and publishes a PutEvent to matching subscribers.
+func (n *NotifyingStorage) Put(ctx context.Context, entry StorageEntry) error {
+	if err := n.Storage.Put(ctx, entry); err != nil {
+		return err
+	}
+
+	n.publish(Event{Type: PutEvent, Key: entry.Key, Value: entry.Value})
+	return nil
+}
+
+// Delete removes key and publishes a DeleteEvent to matching subscribers.
+func (n *NotifyingStorage) Delete(ctx context.Context, key string) error {
+	if err := n.Storage.Delete(ctx, key); err != nil {
+		return err
+	}
+
+	n.publish(Event{Type: DeleteEvent, Key: key})
+	return nil
+}
+
+// Subscribe implements Notifier. The returned channel is closed once ctx is
+// done; publishes to a subscriber that isn't keeping up are dropped rather
+// than blocking the writer that triggered them.
+func (n *NotifyingStorage) Subscribe(ctx context.Context, pathPrefix string) (<-chan Event, error) {
+	ch, _, err := n.SubscribeAt(ctx, pathPrefix)
+	return ch, err
+}
+
+// SubscribeAt behaves like Subscribe but also returns the revision current
+// at the moment the channel was registered, computed under the same lock
+// as the registration itself. Callers should replay buffered events with a
+// revision <= that snapshot and rely purely on the channel for anything
+// after it; replaying anything newer risks delivering it twice, since a
+// publish racing the subscription could otherwise land in both places.
+func (n *NotifyingStorage) SubscribeAt(ctx context.Context, pathPrefix string) (<-chan Event, uint64, error) {
+	ch := make(chan Event, subscriberBuffer)
+
+	n.mu.Lock()
+	n.subscribers[pathPrefix] = append(n.subscribers[pathPrefix], ch)
+	subscribedAt := n.revision
+	n.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		n.mu.Lock()
+		defer n.mu.Unlock()
+
+		subs := n.subscribers[pathPrefix]
+		for i, candidate := range subs {
+			if candidate == ch {
+				n.subscribers[pathPrefix] = append(subs[:i], subs[i+1:]...)
+				break
+			}
+		}
+		close(ch)
+	}()
+
+	return ch, subscribedAt, nil
+}
+
+// CurrentRevision returns the most recently committed revision.
+func (n *NotifyingStorage) CurrentRevision() uint64 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.revision
+}
+
+// EventsSince returns buffered events under pathPrefix with from < revision
+// <= upTo, for replaying to a client resuming with ?fromRevision=N up to the
+// revision it subscribed at.
+func (n *NotifyingStorage) EventsSince(pathPrefix string, from, upTo uint64) []Event {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	var replay []Event
+	for _, evt := range n.buffer {
+		if evt.Revision > from && evt.Revision <= upTo && strings.HasPrefix(evt.Key, pathPrefix) {
+			replay = append(replay, evt)
+		}
+	}
+
+	return replay
+}
+
+func (n *NotifyingStorage) publish(evt Event) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.revision++
+	evt.Revision = n.revision
+	n.buffer = append(n.buffer, evt)
+	if len(n.buffer) > maxBufferedEvents {
+		n.buffer = append([]Event(nil), n.buffer[len(n.buffer)-maxBufferedEvents:]...)
+	}
+
+	for prefix, subs := range n.subscribers {
+		if !strings.HasPrefix(evt.Key, prefix) {
+			continue
+		}
+
+		for _, ch := range subs {
+			select {
+			case ch <- evt:
+			default:
+			}
+		}
+	}
+}
@@ -0,0 +1,212 @@
+// Package snapshot takes point-in-time exports of a storage.Storage and
+// restores them into an empty backend, for scripted backups and for seeding
+// a fresh agent from a running one.
+package snapshot
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/steviebps/realm/pkg/storage"
+)
+
+// schemaVersion is bumped whenever the on-wire framing changes in a way
+// that isn't backwards compatible.
+const schemaVersion uint32 = 1
+
+// sumSize is the length, in bytes, of the trailing SHA-256 that closes a
+// snapshot stream.
+const sumSize = sha256.Size
+
+// Header is the framing record written once at the start of every
+// snapshot stream, ahead of its chunks.
+type Header struct {
+	SchemaVersion  uint32
+	SourceRevision uint64
+	EntryCount     uint64
+}
+
+// Snapshotter takes and restores point-in-time exports of a Storage.
+type Snapshotter struct {
+	Storage storage.Storage
+}
+
+// New returns a Snapshotter backed by s.
+func New(s storage.Storage) *Snapshotter {
+	return &Snapshotter{Storage: s}
+}
+
+// revisioned is implemented by storage backends that track a monotonic
+// write revision (e.g. storage.NotifyingStorage). When present, its current
+// revision is recorded in the snapshot Header so the archive can be
+// correlated with a watch stream's revisions.
+type revisioned interface {
+	CurrentRevision() uint64
+}
+
+// Create streams a framed archive of every entry beneath prefix: a Header,
+// one {key, valueLen, value} chunk per entry, and a trailing SHA-256 of
+// everything written before it so a partial transfer can be detected.
+func (s *Snapshotter) Create(ctx context.Context, prefix string) (io.ReadCloser, error) {
+	keys, err := s.Storage.List(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var sourceRevision uint64
+	if r, ok := s.Storage.(revisioned); ok {
+		sourceRevision = r.CurrentRevision()
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(s.writeArchive(ctx, pw, sourceRevision, keys))
+	}()
+
+	return pr, nil
+}
+
+func (s *Snapshotter) writeArchive(ctx context.Context, w io.Writer, sourceRevision uint64, keys []string) error {
+	hash := sha256.New()
+	tee := io.MultiWriter(w, hash)
+
+	header := Header{SchemaVersion: schemaVersion, SourceRevision: sourceRevision, EntryCount: uint64(len(keys))}
+	if err := binary.Write(tee, binary.BigEndian, header); err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		entry, err := s.Storage.Get(ctx, key)
+		if err != nil {
+			return err
+		}
+		if err := writeChunk(tee, entry.Key, entry.Value); err != nil {
+			return err
+		}
+	}
+
+	_, err := w.Write(hash.Sum(nil))
+	return err
+}
+
+func writeChunk(w io.Writer, key string, value []byte) error {
+	keyBytes := []byte(key)
+	if err := binary.Write(w, binary.BigEndian, uint32(len(keyBytes))); err != nil {
+		return err
+	}
+	if _, err := w.Write(keyBytes); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, uint64(len(value))); err != nil {
+		return err
+	}
+	_, err := w.Write(value)
+	return err
+}
+
+// RestoreOptions configures Restore.
+type RestoreOptions struct {
+	// Prefix is the key prefix being restored into. Restore refuses to
+	// proceed if it already has entries, unless Force is set.
+	Prefix string
+	// Force allows restoring over a non-empty Prefix, overwriting entries
+	// the archive also contains.
+	Force bool
+	// BatchSize is how many entries Restore writes per batch. Defaults to
+	// 100 if left zero.
+	BatchSize int
+}
+
+const defaultRestoreBatchSize = 100
+
+// Restore validates the archive's Header and trailing checksum, then writes
+// its entries into Storage in batches of opts.BatchSize. The entire archive
+// is read and its checksum verified before anything is written, so a
+// truncated or corrupted archive is rejected without partially populating
+// Storage.
+func (s *Snapshotter) Restore(ctx context.Context, r io.Reader, opts RestoreOptions) error {
+	if !opts.Force {
+		existing, err := s.Storage.List(ctx, opts.Prefix)
+		if err != nil {
+			return err
+		}
+		if len(existing) > 0 {
+			return fmt.Errorf("refusing to restore into non-empty prefix %q without Force", opts.Prefix)
+		}
+	}
+
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultRestoreBatchSize
+	}
+
+	hash := sha256.New()
+	tee := io.TeeReader(r, hash)
+
+	var header Header
+	if err := binary.Read(tee, binary.BigEndian, &header); err != nil {
+		return fmt.Errorf("reading snapshot header: %w", err)
+	}
+	if header.SchemaVersion != schemaVersion {
+		return fmt.Errorf("unsupported snapshot schema version %d", header.SchemaVersion)
+	}
+
+	entries := make([]storage.StorageEntry, 0, header.EntryCount)
+	for i := uint64(0); i < header.EntryCount; i++ {
+		entry, err := readChunk(tee)
+		if err != nil {
+			return fmt.Errorf("reading snapshot entry %d of %d: %w", i+1, header.EntryCount, err)
+		}
+		entries = append(entries, entry)
+	}
+
+	want := hash.Sum(nil)
+	got := make([]byte, sumSize)
+	if _, err := io.ReadFull(r, got); err != nil {
+		return fmt.Errorf("reading snapshot checksum: %w", err)
+	}
+	if !bytes.Equal(want, got) {
+		return fmt.Errorf("snapshot checksum mismatch, archive is truncated or corrupt")
+	}
+
+	for start := 0; start < len(entries); start += batchSize {
+		end := start + batchSize
+		if end > len(entries) {
+			end = len(entries)
+		}
+
+		for _, entry := range entries[start:end] {
+			if err := s.Storage.Put(ctx, entry); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func readChunk(r io.Reader) (storage.StorageEntry, error) {
+	var keyLen uint32
+	if err := binary.Read(r, binary.BigEndian, &keyLen); err != nil {
+		return storage.StorageEntry{}, err
+	}
+	keyBytes := make([]byte, keyLen)
+	if _, err := io.ReadFull(r, keyBytes); err != nil {
+		return storage.StorageEntry{}, err
+	}
+
+	var valueLen uint64
+	if err := binary.Read(r, binary.BigEndian, &valueLen); err != nil {
+		return storage.StorageEntry{}, err
+	}
+	value := make([]byte, valueLen)
+	if _, err := io.ReadFull(r, value); err != nil {
+		return storage.StorageEntry{}, err
+	}
+
+	return storage.StorageEntry{Key: string(keyBytes), Value: value}, nil
+}
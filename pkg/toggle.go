@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"sort"
 
 	"golang.org/x/mod/semver"
 )
@@ -24,13 +25,88 @@ type Toggle struct {
 	Type      ToggleType  `json:"type"`
 	Value     interface{} `json:"value"`
 	Overrides []*Override `json:"overrides,omitempty"`
-	// ToggleValidator ToggleValidator `json:"-"`
+
+	// Validator names a registered ToggleValidator this toggle uses in
+	// place of the default ToggleType check. Built in: "jsonschema", "cel".
+	Validator string `json:"validator,omitempty"`
+	// Schema configures the "jsonschema" validator: Value and every
+	// Override.Value must satisfy this JSON Schema.
+	Schema json.RawMessage `json:"schema,omitempty"`
+	// Rule configures the "cel" validator: a CEL expression over `value`
+	// that must evaluate to true, e.g. "value.rolloutPct >= 0 && value.rolloutPct <= 100".
+	Rule string `json:"rule,omitempty"`
+
+	validator ToggleValidator
+
+	// overrideIndex holds Overrides sorted ascending by MinimumVersion, for
+	// GetOverride's binary search. UnmarshalJSON builds it eagerly, once,
+	// via a pointer receiver before the Toggle is ever copied by value -
+	// unlike a sync.Once-guarded cache, a plain slice field doesn't make
+	// toggleAlias's whole-struct conversion in UnmarshalJSON trip go vet's
+	// copylock check, and it doesn't need a package-level map keyed by
+	// *Toggle to hold it, which would otherwise keep every Toggle ever
+	// unmarshaled alive for the life of the process.
+	overrideIndex []*Override
+}
+
+// ToggleValidator validates a toggle's Value and each Override.Value, and
+// resolves the value the toggle reports at a given SDK version. The default
+// validator is the primitive ToggleType check; a named validator replaces
+// that check rather than running alongside it. Register a factory for a
+// named validator with RegisterValidator; toggles opt in via their
+// "validator" field.
+type ToggleValidator interface {
+	ValidateValue(value interface{}) error
+	GetValueAt(version string) interface{}
+}
+
+// ValidatorFactory builds a ToggleValidator for a toggle, typically reading
+// the toggle's own fields (Schema, Rule, ...) for its configuration.
+type ValidatorFactory func(t *Toggle) (ToggleValidator, error)
+
+var validatorFactories = map[string]ValidatorFactory{}
+
+// RegisterValidator registers a named ToggleValidator factory so toggles can
+// opt into it via their "validator" field. Intended to be called from an
+// init function of the package implementing the validator.
+func RegisterValidator(name string, factory ValidatorFactory) {
+	validatorFactories[name] = factory
+}
+
+// defaultValidator is the ToggleValidator every toggle gets unless it names
+// one explicitly: the primitive ToggleType check IsValidValue has always
+// performed.
+type defaultValidator struct {
+	toggle *Toggle
+}
+
+func (d *defaultValidator) ValidateValue(value interface{}) error {
+	if !d.toggle.IsValidValue(value) {
+		return fmt.Errorf("%v (%T) not of the type %q from the toggle: %s", value, value, d.toggle.Type, d.toggle.Name)
+	}
+	return nil
+}
+
+func (d *defaultValidator) GetValueAt(version string) interface{} {
+	if version != "" {
+		if override := d.toggle.GetOverride(version); override != nil {
+			return override.Value
+		}
+	}
+	return d.toggle.Value
 }
 
-// type ToggleValidator interface {
-// 	ValidateValue(value interface{}) bool
-// 	GetValueAt(version string) interface{}
-// }
+func (t *Toggle) buildValidator() (ToggleValidator, error) {
+	if t.Validator == "" {
+		return &defaultValidator{toggle: t}, nil
+	}
+
+	factory, ok := validatorFactories[t.Validator]
+	if !ok {
+		return nil, fmt.Errorf("unknown toggle validator %q from the toggle: %s", t.Validator, t.Name)
+	}
+	return factory(t)
+}
 
 type toggleAlias Toggle
 
@@ -64,8 +140,14 @@ func (t *Toggle) UnmarshalJSON(b []byte) error {
 	}
 	*t = alias.toToggle()
 
-	if !t.IsValidValue(t.Value) {
-		return fmt.Errorf("%v (%T) not of the type %q from the toggle: %s", t.Value, t.Value, t.Type, t.Name)
+	validator, err := t.buildValidator()
+	if err != nil {
+		return err
+	}
+	t.validator = validator
+
+	if err := t.validator.ValidateValue(t.Value); err != nil {
+		return err
 	}
 
 	var previous *Override
@@ -75,19 +157,25 @@ func (t *Toggle) UnmarshalJSON(b []byte) error {
 			return fmt.Errorf("an override with maximum version %v is semantically greater than the next override's minimum version (%v) ", previous.MaximumVersion, override.MinimumVersion)
 		}
 
-		if !t.IsValidValue(override.Value) {
-			return fmt.Errorf("%v (%T) not of the type %q from the toggle override: %s", override.Value, override.Value, t.Type, t.Name)
+		if err := t.validator.ValidateValue(override.Value); err != nil {
+			return err
 		}
 
 		previous = override
 	}
 
+	t.overrideIndex = buildOverrideIndex(t.Overrides)
+
 	return nil
 }
 
 // GetValueAt returns the value at the given version.
 // Will return default value if version is empty string or no override is present for the specified version
 func (t *Toggle) GetValueAt(version string) interface{} {
+	if t.validator != nil {
+		return t.validator.GetValueAt(version)
+	}
+
 	if version != "" {
 		if override := t.GetOverride(version); override != nil {
 			return override.Value
@@ -97,13 +185,42 @@ func (t *Toggle) GetValueAt(version string) interface{} {
 	return t.Value
 }
 
-// GetOverride returns the first override that encapsulates version passed
+// buildOverrideIndex returns overrides sorted ascending by MinimumVersion,
+// the order GetOverride's binary search requires. UnmarshalJSON already
+// guarantees overrides don't overlap by the time this runs.
+func buildOverrideIndex(overrides []*Override) []*Override {
+	sorted := make([]*Override, len(overrides))
+	copy(sorted, overrides)
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return semver.Compare(sorted[i].MinimumVersion, sorted[j].MinimumVersion) < 0
+	})
+
+	return sorted
+}
+
+// GetOverride returns the override that encapsulates version passed.
+//
+// UnmarshalJSON builds overrideIndex once, up front, so this is ordinarily
+// just a binary search for the last override starting at or before version,
+// followed by a single check that it also extends to cover it. Toggles
+// built without going through UnmarshalJSON (e.g. in tests) won't have an
+// index yet, so fall back to building one on the spot.
 func (t *Toggle) GetOverride(version string) *Override {
+	if t.overrideIndex == nil && len(t.Overrides) > 0 {
+		t.overrideIndex = buildOverrideIndex(t.Overrides)
+	}
 
-	for _, override := range t.Overrides {
-		if semver.Compare(override.MinimumVersion, version) <= 0 && semver.Compare(override.MaximumVersion, version) >= 0 {
-			return override
-		}
+	i := sort.Search(len(t.overrideIndex), func(i int) bool {
+		return semver.Compare(t.overrideIndex[i].MinimumVersion, version) > 0
+	})
+	if i == 0 {
+		return nil
+	}
+
+	candidate := t.overrideIndex[i-1]
+	if semver.Compare(candidate.MaximumVersion, version) >= 0 {
+		return candidate
 	}
 
 	return nil
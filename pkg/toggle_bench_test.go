@@ -0,0 +1,66 @@
+package realm
+
+import (
+	"fmt"
+	"testing"
+
+	"golang.org/x/mod/semver"
+)
+
+const (
+	benchToggleCount        = 1000
+	benchOverridesPerToggle = 20
+)
+
+// newBenchToggle builds a toggle with benchOverridesPerToggle
+// non-overlapping, ascending semver-ranged overrides.
+func newBenchToggle(i int) *Toggle {
+	t := &Toggle{Name: fmt.Sprintf("toggle-%d", i), Type: booleanType, Value: true}
+	for j := 0; j < benchOverridesPerToggle; j++ {
+		t.Overrides = append(t.Overrides, &Override{
+			MinimumVersion: fmt.Sprintf("v1.%d.0", j*2),
+			MaximumVersion: fmt.Sprintf("v1.%d.0", j*2+1),
+			Value:          true,
+		})
+	}
+	return t
+}
+
+// linearGetOverride is the pre-index implementation GetOverride used to
+// have: a plain scan over Overrides in declaration order.
+func linearGetOverride(t *Toggle, version string) *Override {
+	for _, override := range t.Overrides {
+		if semver.Compare(override.MinimumVersion, version) <= 0 && semver.Compare(override.MaximumVersion, version) >= 0 {
+			return override
+		}
+	}
+	return nil
+}
+
+func benchToggles() []*Toggle {
+	toggles := make([]*Toggle, benchToggleCount)
+	for i := range toggles {
+		toggles[i] = newBenchToggle(i)
+	}
+	return toggles
+}
+
+func BenchmarkGetOverride_Linear(b *testing.B) {
+	toggles := benchToggles()
+	version := fmt.Sprintf("v1.%d.0", benchOverridesPerToggle)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		linearGetOverride(toggles[i%benchToggleCount], version)
+	}
+}
+
+func BenchmarkGetOverride_Indexed(b *testing.B) {
+	toggles := benchToggles()
+	version := fmt.Sprintf("v1.%d.0", benchOverridesPerToggle)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		toggles[i%benchToggleCount].GetOverride(version)
+	}
+}
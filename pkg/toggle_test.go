@@ -0,0 +1,77 @@
+package realm
+
+import (
+	"fmt"
+	"testing"
+)
+
+// TestGetOverride checks GetOverride's binary-search index against
+// linearGetOverride, the plain scan it replaced, across exact override
+// boundaries, versions inside an override's range, and versions that fall
+// in the gap between overrides -- the places a semver-comparison off-by-one
+// would show up.
+func TestGetOverride(t *testing.T) {
+	toggle := newBenchToggle(0)
+
+	for j := 0; j <= benchOverridesPerToggle; j++ {
+		versions := []string{
+			fmt.Sprintf("v1.%d.0", j*2),   // exact MinimumVersion
+			fmt.Sprintf("v1.%d.0", j*2+1), // exact MaximumVersion
+			fmt.Sprintf("v1.%d.5", j*2),   // inside the override's range
+			fmt.Sprintf("v1.%d.5", j*2+1), // in the gap after the override's range
+		}
+
+		for _, version := range versions {
+			want := linearGetOverride(toggle, version)
+			got := toggle.GetOverride(version)
+			if got != want {
+				t.Errorf("GetOverride(%q) = %v, want %v (linear scan)", version, got, want)
+			}
+		}
+	}
+
+	// Versions entirely before the first and after the last override.
+	for _, version := range []string{"v0.9.0", fmt.Sprintf("v1.%d.0", benchOverridesPerToggle*2+5)} {
+		want := linearGetOverride(toggle, version)
+		got := toggle.GetOverride(version)
+		if got != want {
+			t.Errorf("GetOverride(%q) = %v, want %v (linear scan)", version, got, want)
+		}
+	}
+}
+
+// TestGetOverride_Unmarshaled checks that a Toggle built the normal way,
+// through UnmarshalJSON, whose overrideIndex is built eagerly there, finds
+// an override for versions inside either override's range and none for
+// versions in the gap between them or after the last one.
+func TestGetOverride_Unmarshaled(t *testing.T) {
+	raw := []byte(`{
+		"name": "unmarshaled-toggle",
+		"type": "boolean",
+		"value": true,
+		"overrides": [
+			{"minimumVersion": "v1.0.0", "maximumVersion": "v1.1.0", "value": true},
+			{"minimumVersion": "v1.2.0", "maximumVersion": "v1.3.0", "value": false}
+		]
+	}`)
+
+	var toggle Toggle
+	if err := toggle.UnmarshalJSON(raw); err != nil {
+		t.Fatalf("UnmarshalJSON: %v", err)
+	}
+
+	for _, tc := range []struct {
+		version string
+		want    bool
+	}{
+		{"v1.0.5", true},  // inside the first override
+		{"v1.1.5", false}, // in the gap
+		{"v1.2.5", true},  // inside the second override
+		{"v1.4.0", false}, // after the last override
+	} {
+		override := toggle.GetOverride(tc.version)
+		if got := override != nil; got != tc.want {
+			t.Errorf("GetOverride(%q) found override = %v, want %v", tc.version, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package realm
+
+import (
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+)
+
+func init() {
+	RegisterValidator("cel", newCELValidator)
+}
+
+// celValidator validates a toggle's Value and Override values by evaluating
+// a CEL expression over `value`, declared on the toggle's "rule" field, e.g.
+// "value.rolloutPct >= 0 && value.rolloutPct <= 100".
+type celValidator struct {
+	toggle *Toggle
+	prg    cel.Program
+}
+
+func newCELValidator(t *Toggle) (ToggleValidator, error) {
+	if t.Rule == "" {
+		return nil, fmt.Errorf("toggle %s: \"cel\" validator requires a \"rule\" field", t.Name)
+	}
+
+	env, err := cel.NewEnv(cel.Variable("value", cel.DynType))
+	if err != nil {
+		return nil, fmt.Errorf("toggle %s: building CEL environment: %w", t.Name, err)
+	}
+
+	ast, issues := env.Compile(t.Rule)
+	if issues != nil && issues.Err() != nil {
+		return nil, fmt.Errorf("toggle %s: compiling rule %q: %w", t.Name, t.Rule, issues.Err())
+	}
+
+	prg, err := env.Program(ast)
+	if err != nil {
+		return nil, fmt.Errorf("toggle %s: building CEL program: %w", t.Name, err)
+	}
+
+	return &celValidator{toggle: t, prg: prg}, nil
+}
+
+// ValidateValue implements ToggleValidator.
+func (v *celValidator) ValidateValue(value interface{}) error {
+	out, _, err := v.prg.Eval(map[string]interface{}{"value": value})
+	if err != nil {
+		return fmt.Errorf("toggle %s: evaluating rule %q: %w", v.toggle.Name, v.toggle.Rule, err)
+	}
+
+	ok, isBool := out.Value().(bool)
+	if !isBool {
+		return fmt.Errorf("toggle %s: rule %q did not evaluate to a bool", v.toggle.Name, v.toggle.Rule)
+	}
+	if !ok {
+		return fmt.Errorf("toggle %s: value %v failed rule %q", v.toggle.Name, value, v.toggle.Rule)
+	}
+
+	return nil
+}
+
+// GetValueAt implements ToggleValidator.
+func (v *celValidator) GetValueAt(version string) interface{} {
+	if version != "" {
+		if override := v.toggle.GetOverride(version); override != nil {
+			return override.Value
+		}
+	}
+
+	return v.toggle.Value
+}
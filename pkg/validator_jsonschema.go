@@ -0,0 +1,71 @@
+package realm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+func init() {
+	RegisterValidator("jsonschema", newJSONSchemaValidator)
+}
+
+// jsonSchemaValidator validates a customType toggle's Value and Override
+// values against a JSON Schema declared on the toggle's "schema" field.
+type jsonSchemaValidator struct {
+	toggle *Toggle
+	schema *jsonschema.Schema
+}
+
+func newJSONSchemaValidator(t *Toggle) (ToggleValidator, error) {
+	if len(t.Schema) == 0 {
+		return nil, fmt.Errorf("toggle %s: \"jsonschema\" validator requires a \"schema\" field", t.Name)
+	}
+
+	compiler := jsonschema.NewCompiler()
+	resourceName := t.Name + ".json"
+	if err := compiler.AddResource(resourceName, bytes.NewReader(t.Schema)); err != nil {
+		return nil, fmt.Errorf("toggle %s: compiling schema: %w", t.Name, err)
+	}
+
+	schema, err := compiler.Compile(resourceName)
+	if err != nil {
+		return nil, fmt.Errorf("toggle %s: compiling schema: %w", t.Name, err)
+	}
+
+	return &jsonSchemaValidator{toggle: t, schema: schema}, nil
+}
+
+// ValidateValue implements ToggleValidator.
+func (v *jsonSchemaValidator) ValidateValue(value interface{}) error {
+	// round-trip through JSON so a map[string]interface{} decoded by
+	// encoding/json validates the same way the raw document would
+	b, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return err
+	}
+
+	if err := v.schema.Validate(doc); err != nil {
+		return fmt.Errorf("toggle %s: value does not satisfy schema: %w", v.toggle.Name, err)
+	}
+
+	return nil
+}
+
+// GetValueAt implements ToggleValidator.
+func (v *jsonSchemaValidator) GetValueAt(version string) interface{} {
+	if version != "" {
+		if override := v.toggle.GetOverride(version); override != nil {
+			return override.Value
+		}
+	}
+
+	return v.toggle.Value
+}